@@ -4,8 +4,27 @@ import (
 	"context"
 	"fmt"
 	client "github.com/titan-data/titan-client-go"
+	"github.com/titan-data/titan/internal/app/providers/local/cache"
+	"github.com/titan-data/titan/internal/app/providers/local/container"
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+	_ "github.com/titan-data/titan/internal/app/providers/local/distro/arch"
+	_ "github.com/titan-data/titan/internal/app/providers/local/distro/centos"
+	_ "github.com/titan-data/titan/internal/app/providers/local/distro/debian"
+	_ "github.com/titan-data/titan/internal/app/providers/local/distro/fedora"
+	_ "github.com/titan-data/titan/internal/app/providers/local/distro/ubuntu"
+	"github.com/titan-data/titan/internal/app/providers/local/kernels"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
+)
+
+const (
+	defaultZFSVersion    = "0.8.2"
+	defaultZFSConfig     = "kernel"
+	defaultHeadersSource = "/usr/src"
+	buildTimeout         = 30 * time.Minute
 )
 
 func init() {
@@ -20,7 +39,30 @@ var repositoriesApi = apiClient.RepositoriesApi
 var volumesApi = apiClient.VolumesApi
 var ctx = context.Background()
 
+// getKernel detects the running kernel. On native Linux it dispatches to the
+// host distro's own detection (/etc/os-release + `uname -r`); everywhere
+// else it falls back to probing the Docker Desktop / LinuxKit VM.
 func getKernel() string {
+	if runtime.GOOS == "linux" {
+		d, err := distro.Detect()
+		if err != nil {
+			fmt.Println("Unable to detect Linux distribution:", err)
+			os.Exit(1)
+		}
+		k, err := d.DetectKernel()
+		if err != nil {
+			fmt.Println("Unable to locate kernel version")
+			os.Exit(1)
+		}
+		return k
+	}
+	return getLinuxKitKernel()
+}
+
+// getLinuxKitKernel probes the Docker Desktop LinuxKit VM for its kernel
+// image tag, the only way to detect the kernel when Titan itself isn't
+// running on the Linux box doing the work.
+func getLinuxKitKernel() string {
 	var args = []string{"run", "--rm", "-i", "--privileged", "--pid=host", "alpine:latest",
 		"nsenter", "-t", "1", "-m", "-u", "-n", "-i", "awk",
 		"{ if ($1 == \"kernel:\") { inKernel = 1; next } if (inKernel == 1 && $1 == \"image:\") { print $2; inKernel = 0; quit } }",
@@ -38,7 +80,18 @@ func getTag(k string) string {
 	return strings.Split(c, "-")[0]
 }
 
+// zfsInstalled dispatches to the host distro's own check on native Linux and
+// falls back to inspecting the Docker Desktop VM otherwise.
 func zfsInstalled() bool {
+	if runtime.GOOS == "linux" {
+		d, err := distro.Detect()
+		if err != nil {
+			return false
+		}
+		installed, _ := d.ZFSInstalled()
+		return installed
+	}
+
 	mod, _ := ce.Exec("docker", "run", "alpine:latest", "lsmod")
 	for _, l := range strings.Split(mod, "\n") {
 		for i, w := range strings.Split(l, " ") {
@@ -50,46 +103,182 @@ func zfsInstalled() bool {
 	return false
 }
 
-func installZFS(tag string) {
+func installZFS(k string) {
+	if runtime.GOOS == "linux" {
+		d, err := distro.Detect()
+		if err != nil {
+			fmt.Println("Unable to detect Linux distribution:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Installing ZFS for " + d.ID())
+		if err := d.InstallZFS(k); err != nil {
+			fmt.Println("Unable to install ZFS:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("Installing ZFS for Docker Desktop")
-	out, err := ce.Exec("docker", "run", "--privileged", "--rm", "titandata/docker-desktop-zfs-kernel:" + tag)
+
+	tag := getTag(k)
+	release := strings.Split(k, ":")[1]
+
+	kcfg, err := kernels.Load(kernels.DefaultPath())
 	if err != nil {
-		if strings.Contains(out, "manifest unknown") {
-			fmt.Println("Pre-built ZFS kernel modules not available for kernel version " + tag)
-			fmt.Println("Falling back to building ZFS from source...")
-			buildZFSFromSource(tag)
-		} else {
+		fmt.Println("Unable to load kernel config:", err)
+		os.Exit(1)
+	}
+
+	images := kcfg.Match(release)
+	if len(images) == 0 {
+		images = []string{"titandata/docker-desktop-zfs-kernel:" + tag}
+	}
+
+	for _, image := range images {
+		digest, out, err := pullImage(image)
+		if err != nil {
+			if !strings.Contains(out, "manifest unknown") {
+				fmt.Println("Unable to install ZFS for Docker Desktop")
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Println("Pre-built ZFS kernel modules not available: " + image)
+			continue
+		}
+
+		if path, ok := cache.Lookup(tag, defaultZFSVersion, digest, "", ""); ok {
+			fmt.Println("Using cached ZFS kernel modules for " + tag)
+			if err := loadCachedModule(path); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		modulesDir := cache.Dir(tag, defaultZFSVersion)
+		if err := os.MkdirAll(modulesDir, 0755); err != nil {
+			fmt.Println("Unable to create cache dir:", err)
+			os.Exit(1)
+		}
+
+		runArgs := []string{
+			"run", "--privileged", "--rm",
+			"-v", modulesDir + ":/lib/modules/extra",
+			image,
+		}
+		if _, err := ce.Exec("docker", runArgs...); err != nil {
 			fmt.Println("Unable to install ZFS for Docker Desktop")
 			fmt.Println(err)
 			os.Exit(1)
 		}
+
+		path := filepath.Join(modulesDir, "zfs.ko")
+		if err := cache.Store(tag, defaultZFSVersion, digest, "", "", path); err != nil {
+			fmt.Println("Warning: unable to cache installed modules:", err)
+		}
+		return
+	}
+
+	fmt.Println("Falling back to building ZFS from source...")
+	if err := buildZFSFromSource(tag, "", "", ""); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// pullImage pulls image and returns its local digest (docker inspect's
+// .Id), so that a repeat install of the same tag can be recognized as a
+// cache hit instead of re-running the privileged install container.
+func pullImage(image string) (digest string, out string, err error) {
+	out, err = ce.Exec("docker", "pull", image)
+	if err != nil {
+		return "", out, err
 	}
+	idOut, err := ce.Exec("docker", "inspect", "--format={{.Id}}", image)
+	if err != nil {
+		return "", idOut, err
+	}
+	return strings.TrimSpace(idOut), "", nil
 }
 
-func buildZFSFromSource(tag string) {
+// buildZFSFromSource compiles ZFS kernel modules for tag using a reproducible
+// builder container, consulting and populating the local cache. zfsVersion,
+// zfsConfig, and headersSource default to defaultZFSVersion, defaultZFSConfig,
+// and defaultHeadersSource respectively when empty. It returns an error
+// rather than exiting so that callers such as the genall matrix builder can
+// retry transient failures without tearing down the whole process.
+func buildZFSFromSource(tag, zfsVersion, zfsConfig, headersSource string) error {
+	if zfsVersion == "" {
+		zfsVersion = defaultZFSVersion
+	}
+	if zfsConfig == "" {
+		zfsConfig = defaultZFSConfig
+	}
+	if headersSource == "" {
+		headersSource = defaultHeadersSource
+	}
+
+	builderImage := "titandata/zfs-builder:latest"
+	c := container.New(tag, buildTimeout)
+
+	if err := c.Build(builderImage); err != nil {
+		return fmt.Errorf("failed to build ZFS from source for kernel %s: %w", tag, err)
+	}
+
+	digestOut, _ := ce.Exec("docker", "inspect", "--format={{.Id}}", builderImage)
+	digest := strings.TrimSpace(digestOut)
+
+	if path, ok := cache.Lookup(tag, zfsVersion, digest, zfsConfig, headersSource); ok {
+		fmt.Println("Using cached ZFS kernel modules for " + tag)
+		return loadCachedModule(path)
+	}
+
 	fmt.Println("Building ZFS kernel modules from source (this may take 10-30 minutes)...")
-	
-	// Use the zfs-builder to compile modules for the current kernel
-	buildArgs := []string{
-		"run", "--rm", "--privileged",
-		"-v", "/var/run/docker.sock:/var/run/docker.sock",
-		"-e", "ZFS_VERSION=zfs-0.8.2",
-		"-e", "ZFS_CONFIG=kernel",
-		"titandata/zfs-builder:latest",
-	}
-	
-	out, err := ce.Exec("docker", buildArgs...)
+
+	logPath := filepath.Join(logsDir(), fmt.Sprintf("zfs-build-%d.log", time.Now().Unix()))
+	env := map[string]string{
+		"ZFS_VERSION":        "zfs-" + zfsVersion,
+		"ZFS_CONFIG":         zfsConfig,
+		"KERNEL_HEADERS_SRC": headersSource,
+	}
+
+	if err := c.Run(builderImage, env, logPath); err != nil {
+		return fmt.Errorf("failed to build ZFS from source for kernel %s (see %s):\n%w\n\n"+
+			"You may need to:\n"+
+			"1. Ensure Docker Desktop is using a supported kernel version\n"+
+			"2. Try a different Docker Desktop version\n"+
+			"3. Install ZFS manually on your system", tag, logPath, err)
+	}
+
+	fmt.Println("ZFS kernel modules built successfully; logs at " + logPath)
+
+	path := filepath.Join(c.Volumes.LibModules, "zfs.ko")
+	if err := cache.Store(tag, zfsVersion, digest, zfsConfig, headersSource, path); err != nil {
+		fmt.Println("Warning: unable to cache built modules:", err)
+	}
+	return nil
+}
+
+// logsDir returns ~/.titan/logs, where per-build logs are written.
+func logsDir() string {
+	home, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Println("Failed to build ZFS from source:")
-		fmt.Println(out)
-		fmt.Println("Error:", err)
-		fmt.Println("")
-		fmt.Println("You may need to:")
-		fmt.Println("1. Ensure Docker Desktop is using a supported kernel version")
-		fmt.Println("2. Try a different Docker Desktop version")
-		fmt.Println("3. Install ZFS manually on your system")
-		os.Exit(1)
+		home = "."
+	}
+	return filepath.Join(home, ".titan", "logs")
+}
+
+// loadCachedModule loads a previously built or downloaded .ko from the local
+// cache via a small privileged loader container, instead of rebuilding or
+// re-pulling it.
+func loadCachedModule(path string) error {
+	args := []string{
+		"run", "--rm", "--privileged", "--pid=host",
+		"-v", path + ":/lib/modules/zfs.ko",
+		"alpine:latest", "insmod", "/lib/modules/zfs.ko",
+	}
+	if _, err := ce.Exec("docker", args...); err != nil {
+		return fmt.Errorf("Unable to load cached ZFS kernel modules: %w", err)
 	}
-	
-	fmt.Println("ZFS kernel modules built successfully")
+	return nil
 }
\ No newline at end of file
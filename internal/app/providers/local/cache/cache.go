@@ -0,0 +1,149 @@
+// Package cache stores built and downloaded ZFS kernel module artifacts on
+// disk so that repeated installs/reboots for the same (kernel, ZFS version)
+// pair don't pay the rebuild or re-pull cost every time.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Receipt records a cached install: where the .ko artifact lives and what
+// produced it.
+type Receipt struct {
+	KernelTag     string    `json:"kernel_tag"`
+	ZFSVersion    string    `json:"zfs_version"`
+	BuilderDigest string    `json:"builder_image_digest"`
+	ZFSConfig     string    `json:"zfs_config"`
+	HeadersSource string    `json:"headers_source"`
+	Path          string    `json:"path"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const receiptFile = "receipt.json"
+
+// Dir returns the on-disk directory for a given (kernel, ZFS version) pair:
+// ~/.titan/cache/zfs/<kernel-tag>/<zfs-version>/
+func Dir(kernelTag, zfsVersion string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".titan", "cache", "zfs", kernelTag, zfsVersion)
+}
+
+// Lookup returns the cached artifact path for (kernelTag, zfsVersion,
+// builderDigest, zfsConfig, headersSource), if one exists on disk. A stored
+// receipt whose BuilderDigest, ZFSConfig, or HeadersSource doesn't match is
+// treated as a miss, so that an updated builder image (bug fix, new ZFS
+// patch level) or a different build configuration invalidates stale cached
+// modules instead of silently reusing them.
+func Lookup(kernelTag, zfsVersion, builderDigest, zfsConfig, headersSource string) (string, bool) {
+	r, err := readReceipt(Dir(kernelTag, zfsVersion))
+	if err != nil {
+		return "", false
+	}
+	if r.BuilderDigest != builderDigest || r.ZFSConfig != zfsConfig || r.HeadersSource != headersSource {
+		return "", false
+	}
+	if _, err := os.Stat(r.Path); err != nil {
+		return "", false
+	}
+	return r.Path, true
+}
+
+// Store records that path holds the built/downloaded artifact for
+// (kernelTag, zfsVersion), keyed additionally by builderDigest, zfsConfig,
+// and headersSource.
+func Store(kernelTag, zfsVersion, builderDigest, zfsConfig, headersSource, path string) error {
+	dir := Dir(kernelTag, zfsVersion)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating cache dir %s: %w", dir, err)
+	}
+	r := Receipt{
+		KernelTag:     kernelTag,
+		ZFSVersion:    zfsVersion,
+		BuilderDigest: builderDigest,
+		ZFSConfig:     zfsConfig,
+		HeadersSource: headersSource,
+		Path:          path,
+		CreatedAt:     time.Now(),
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cache receipt: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, receiptFile), b, 0644)
+}
+
+// List returns every receipt currently in the cache.
+func List() ([]Receipt, error) {
+	root := filepath.Join(cacheRoot(), "zfs")
+	var receipts []Receipt
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || d.Name() != receiptFile {
+			return nil
+		}
+		r, err := readReceipt(filepath.Dir(path))
+		if err != nil {
+			return nil
+		}
+		receipts = append(receipts, r)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// Prune removes cache entries older than maxAge.
+func Prune(maxAge time.Duration) error {
+	receipts, err := List()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, r := range receipts {
+		if r.CreatedAt.Before(cutoff) {
+			if err := os.RemoveAll(Dir(r.KernelTag, r.ZFSVersion)); err != nil {
+				return fmt.Errorf("pruning %s/%s: %w", r.KernelTag, r.ZFSVersion, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Clear removes the entire cache.
+func Clear() error {
+	return os.RemoveAll(filepath.Join(cacheRoot(), "zfs"))
+}
+
+func cacheRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".titan", "cache")
+}
+
+func readReceipt(dir string) (Receipt, error) {
+	var r Receipt
+	b, err := os.ReadFile(filepath.Join(dir, receiptFile))
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, err
+	}
+	return r, nil
+}
@@ -0,0 +1,134 @@
+// Package container gives the ZFS builder a reproducible, inspectable set of
+// persistent volumes instead of a throwaway layer, so that failed builds can
+// be debugged and the cache subsystem can find the artifacts they produce.
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Volumes are the host paths mounted into a builder container. They persist
+// across runs so that `lib/modules`, `usr/src`, and `boot` behave like a real
+// machine's rather than being discarded with the container.
+type Volumes struct {
+	LibModules string
+	UsrSrc     string
+	Boot       string
+	Cache      string
+}
+
+// Container runs a single named builder with its own persistent Volumes and
+// a per-Run timeout.
+type Container struct {
+	Name    string
+	Timeout time.Duration
+	Volumes Volumes
+}
+
+// New returns a Container named name, rooted at
+// ~/.titan/volumes/zfs-builder/<name>/, with the given default timeout for
+// Build and Run.
+func New(name string, timeout time.Duration) *Container {
+	root := filepath.Join(volumesRoot(), name)
+	return &Container{
+		Name:    name,
+		Timeout: timeout,
+		Volumes: Volumes{
+			LibModules: filepath.Join(root, "lib-modules"),
+			UsrSrc:     filepath.Join(root, "usr-src"),
+			Boot:       filepath.Join(root, "boot"),
+			Cache:      filepath.Join(root, "cache"),
+		},
+	}
+}
+
+func volumesRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".titan", "volumes", "zfs-builder")
+}
+
+// ensureVolumes creates the container's host volume directories if they
+// don't already exist.
+func (c *Container) ensureVolumes() error {
+	for _, dir := range []string{c.Volumes.LibModules, c.Volumes.UsrSrc, c.Volumes.Boot, c.Volumes.Cache} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating volume dir %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// Build creates the container's volume directories and pulls image, so that
+// its latest version is present locally.
+func (c *Container) Build(image string) error {
+	if err := c.ensureVolumes(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "docker", "pull", image).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pulling builder image %s: %w\n%s", image, err, out)
+	}
+	return nil
+}
+
+// Run executes image with env set and the container's volumes mounted,
+// appending combined output to logPath if logPath is non-empty. It returns
+// an error if the run fails or exceeds the Container's Timeout.
+func (c *Container) Run(image string, env map[string]string, logPath string) error {
+	if err := c.ensureVolumes(); err != nil {
+		return err
+	}
+
+	args := []string{
+		"run", "--rm", "--privileged",
+		"-v", c.Volumes.LibModules + ":/lib/modules",
+		"-v", c.Volumes.UsrSrc + ":/usr/src",
+		"-v", c.Volumes.Boot + ":/boot",
+		"-v", c.Volumes.Cache + ":/cache",
+	}
+	for k, v := range env {
+		args = append(args, "-e", k+"="+v)
+	}
+	args = append(args, image)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+
+	if logPath != "" {
+		if werr := appendLog(logPath, out); werr != nil {
+			fmt.Fprintln(os.Stderr, "warning: unable to write build log:", werr)
+		}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("container %s timed out after %s", c.Name, c.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("container %s failed: %w\n%s", c.Name, err, out)
+	}
+	return nil
+}
+
+func appendLog(logPath string, out []byte) error {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(out)
+	return err
+}
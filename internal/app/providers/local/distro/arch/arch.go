@@ -0,0 +1,41 @@
+// Package arch installs ZFS natively on Arch Linux via pacman, pulling from
+// the community zfs-dkms AUR/archzfs package.
+package arch
+
+import (
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+)
+
+func init() {
+	distro.Register(Match, New)
+}
+
+// Match reports whether id (the /etc/os-release ID field) is Arch Linux.
+func Match(id string) bool {
+	return id == "arch"
+}
+
+// Arch installs ZFS via `pacman -S zfs-dkms`.
+type Arch struct{}
+
+// New returns an Arch Linux distro.
+func New(distro.Release) distro.Distro {
+	return Arch{}
+}
+
+func (Arch) ID() string {
+	return "arch"
+}
+
+func (Arch) DetectKernel() (string, error) {
+	return distro.UnameKernel()
+}
+
+func (Arch) InstallZFS(kernel string) error {
+	_, err := distro.Run("pacman", "-S", "--noconfirm", "zfs-dkms")
+	return err
+}
+
+func (Arch) ZFSInstalled() (bool, error) {
+	return distro.ModinfoInstalled()
+}
@@ -0,0 +1,68 @@
+// Package centos installs ZFS natively on CentOS/RHEL via yum/dnf and the
+// ZFS on Linux repository.
+package centos
+
+import (
+	"strings"
+
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+)
+
+func init() {
+	distro.Register(Match, New)
+}
+
+// Match reports whether id (the /etc/os-release ID field) is CentOS or RHEL.
+func Match(id string) bool {
+	return id == "centos" || id == "rhel"
+}
+
+// CentOS installs ZFS via the upstream ZFS on Linux repository package for
+// the detected major release, plus `yum install zfs`.
+type CentOS struct {
+	ReleaseID string
+	VersionID string
+}
+
+// New returns a CentOS/RHEL distro carrying the detected ID and VERSION_ID,
+// so InstallZFS can pick the release-matching zfs-release RPM.
+func New(rel distro.Release) distro.Distro {
+	return CentOS{ReleaseID: rel.ID, VersionID: rel.VersionID}
+}
+
+func (c CentOS) ID() string {
+	return c.ReleaseID
+}
+
+func (CentOS) DetectKernel() (string, error) {
+	return distro.UnameKernel()
+}
+
+func (c CentOS) InstallZFS(kernel string) error {
+	if _, err := distro.Run("yum", "install", "-y", zfsReleaseRPM(c.VersionID)); err != nil {
+		return err
+	}
+	_, err := distro.Run("yum", "install", "-y", "zfs")
+	return err
+}
+
+func (CentOS) ZFSInstalled() (bool, error) {
+	return distro.ModinfoInstalled()
+}
+
+// zfsReleaseRPM returns the zfsonlinux.org epel release package matching the
+// major version reported in VERSION_ID, falling back to the latest known
+// 8.x release when the version is unrecognized.
+func zfsReleaseRPM(versionID string) string {
+	major := strings.SplitN(versionID, ".", 2)[0]
+	switch major {
+	case "7":
+		return "https://zfsonlinux.org/epel/zfs-release.el7_9.noarch.rpm"
+	case "8":
+		return "https://zfsonlinux.org/epel/zfs-release.el8_3.noarch.rpm"
+	case "9":
+		return "https://zfsonlinux.org/epel/zfs-release.el9_1.noarch.rpm"
+	default:
+		return "https://zfsonlinux.org/epel/zfs-release.el8_3.noarch.rpm"
+	}
+}
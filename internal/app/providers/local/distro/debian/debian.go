@@ -0,0 +1,44 @@
+// Package debian installs ZFS natively on Debian via apt-get.
+package debian
+
+import (
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+)
+
+func init() {
+	distro.Register(Match, New)
+}
+
+// Match reports whether id (the /etc/os-release ID field) is Debian.
+func Match(id string) bool {
+	return id == "debian"
+}
+
+// Debian installs ZFS via apt-get install zfs-dkms zfsutils-linux, as Ubuntu
+// does. It does not enable the contrib or backports repositories itself.
+type Debian struct{}
+
+// New returns a Debian distro.
+func New(distro.Release) distro.Distro {
+	return Debian{}
+}
+
+func (Debian) ID() string {
+	return "debian"
+}
+
+func (Debian) DetectKernel() (string, error) {
+	return distro.UnameKernel()
+}
+
+func (Debian) InstallZFS(kernel string) error {
+	if _, err := distro.Run("apt-get", "update"); err != nil {
+		return err
+	}
+	_, err := distro.Run("apt-get", "install", "-y", "zfs-dkms", "zfsutils-linux")
+	return err
+}
+
+func (Debian) ZFSInstalled() (bool, error) {
+	return distro.ModinfoInstalled()
+}
@@ -0,0 +1,123 @@
+// Package distro lets Titan install ZFS on bare-metal/native Linux, not just
+// inside a Docker Desktop / LinuxKit VM. Each supported distro lives in its
+// own sub-package (ubuntu, debian, centos, fedora, arch) and registers
+// itself here via a Match function, mirroring the distro-plugin pattern used
+// upstream.
+package distro
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Distro knows how to detect the running kernel and install ZFS on one
+// native Linux distribution.
+type Distro interface {
+	// ID returns the distro's /etc/os-release ID, e.g. "ubuntu".
+	ID() string
+	// DetectKernel returns the running kernel release, e.g. `uname -r`.
+	DetectKernel() (string, error)
+	// InstallZFS installs ZFS for the given kernel release via the
+	// distro's native package manager.
+	InstallZFS(kernel string) error
+	// ZFSInstalled reports whether ZFS is already installed and loadable.
+	ZFSInstalled() (bool, error)
+}
+
+// Release is the subset of /etc/os-release Detect parses and hands to a
+// matching distro's constructor, so that distros whose install path depends
+// on more than just the ID (e.g. CentOS/RHEL needing VersionID) don't have
+// to re-read the file themselves.
+type Release struct {
+	ID        string
+	VersionID string
+}
+
+type matcher struct {
+	match func(id string) bool
+	new   func(Release) Distro
+}
+
+var registry []matcher
+
+// Register adds a distro to the registry. Sub-packages call this from an
+// init() function so that blank-importing them is enough to make them
+// available to Detect.
+func Register(match func(id string) bool, new func(Release) Distro) {
+	registry = append(registry, matcher{match: match, new: new})
+}
+
+// Detect reads /etc/os-release and returns the Distro whose Match function
+// claims the reported ID.
+func Detect() (Distro, error) {
+	rel, err := ParseOSRelease("/etc/os-release")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range registry {
+		if m.match(rel.ID) {
+			return m.new(rel), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported Linux distribution: %s", rel.ID)
+}
+
+// ParseOSRelease extracts the ID and VERSION_ID fields from an
+// /etc/os-release-formatted file.
+func ParseOSRelease(path string) (Release, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Release{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var rel Release
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "ID="):
+			rel.ID = strings.Trim(strings.TrimPrefix(line, "ID="), "\"")
+		case strings.HasPrefix(line, "VERSION_ID="):
+			rel.VersionID = strings.Trim(strings.TrimPrefix(line, "VERSION_ID="), "\"")
+		}
+	}
+	if rel.ID == "" {
+		return Release{}, fmt.Errorf("no ID found in %s", path)
+	}
+	return rel, nil
+}
+
+// Run executes name with args and returns its combined output. Unlike the
+// package-local `ce` helper that the Docker Desktop code path in package
+// local uses, Run has no dependency on package local, so distro and its
+// sub-packages (which local itself imports) can call it without creating an
+// import cycle.
+func Run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	return string(out), err
+}
+
+// UnameKernel returns the running kernel release via `uname -r`. It backs
+// the DetectKernel implementation shared by every natively-installed distro.
+func UnameKernel() (string, error) {
+	v, err := Run("uname", "-r")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// ModinfoInstalled reports whether the zfs module is installed, via modinfo
+// or the presence of /sbin/zfs. It backs the ZFSInstalled implementation
+// shared by every natively-installed distro.
+func ModinfoInstalled() (bool, error) {
+	if _, err := Run("modinfo", "zfs"); err == nil {
+		return true, nil
+	}
+	_, err := Run("test", "-x", "/sbin/zfs")
+	return err == nil, nil
+}
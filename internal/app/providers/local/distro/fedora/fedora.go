@@ -0,0 +1,40 @@
+// Package fedora installs ZFS natively on Fedora via dnf.
+package fedora
+
+import (
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+)
+
+func init() {
+	distro.Register(Match, New)
+}
+
+// Match reports whether id (the /etc/os-release ID field) is Fedora.
+func Match(id string) bool {
+	return id == "fedora"
+}
+
+// Fedora installs ZFS via `dnf install zfs`.
+type Fedora struct{}
+
+// New returns a Fedora distro.
+func New(distro.Release) distro.Distro {
+	return Fedora{}
+}
+
+func (Fedora) ID() string {
+	return "fedora"
+}
+
+func (Fedora) DetectKernel() (string, error) {
+	return distro.UnameKernel()
+}
+
+func (Fedora) InstallZFS(kernel string) error {
+	_, err := distro.Run("dnf", "install", "-y", "zfs")
+	return err
+}
+
+func (Fedora) ZFSInstalled() (bool, error) {
+	return distro.ModinfoInstalled()
+}
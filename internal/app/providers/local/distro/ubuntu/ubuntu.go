@@ -0,0 +1,43 @@
+// Package ubuntu installs ZFS natively on Ubuntu via apt-get.
+package ubuntu
+
+import (
+	"github.com/titan-data/titan/internal/app/providers/local/distro"
+)
+
+func init() {
+	distro.Register(Match, New)
+}
+
+// Match reports whether id (the /etc/os-release ID field) is Ubuntu.
+func Match(id string) bool {
+	return id == "ubuntu"
+}
+
+// Ubuntu installs ZFS via apt-get install zfs-dkms zfsutils-linux.
+type Ubuntu struct{}
+
+// New returns an Ubuntu distro.
+func New(distro.Release) distro.Distro {
+	return Ubuntu{}
+}
+
+func (Ubuntu) ID() string {
+	return "ubuntu"
+}
+
+func (Ubuntu) DetectKernel() (string, error) {
+	return distro.UnameKernel()
+}
+
+func (Ubuntu) InstallZFS(kernel string) error {
+	if _, err := distro.Run("apt-get", "update"); err != nil {
+		return err
+	}
+	_, err := distro.Run("apt-get", "install", "-y", "zfs-dkms", "zfsutils-linux")
+	return err
+}
+
+func (Ubuntu) ZFSInstalled() (bool, error) {
+	return distro.ModinfoInstalled()
+}
@@ -0,0 +1,105 @@
+// Package kernels matches a detected host kernel against a user-editable
+// list of supported kernel/image pairs, so that installZFS knows which
+// pre-built image(s) to try before falling back to building from source.
+package kernels
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// KernelMask is one `[[supported_kernels]]` entry in kernels.toml. ReleaseMask
+// is a regex matched against the full kernel release string (e.g. the output
+// of `uname -r`); Image is the image template to use on a match, with
+// "{{.Tag}}" replaced by the portion of the release before the first '-'.
+type KernelMask struct {
+	DistroType    string   `toml:"distro_type"`
+	DistroRelease string   `toml:"distro_release"`
+	ReleaseMask   string   `toml:"release_mask"`
+	Image         string   `toml:"image"`
+	Kernels       []string `toml:"kernels"`
+}
+
+// Config is the root of ~/.titan/kernels.toml.
+type Config struct {
+	SupportedKernels []KernelMask `toml:"supported_kernels"`
+}
+
+// DefaultPath returns the default location of the kernel-matching config,
+// ~/.titan/kernels.toml.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".titan/kernels.toml"
+	}
+	return filepath.Join(home, ".titan", "kernels.toml")
+}
+
+// Load reads and parses the kernel config at path. A missing file is not an
+// error; it returns an empty Config so callers can fall back to legacy,
+// hardcoded image names.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, fmt.Errorf("parsing kernel config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Match returns the candidate images for every mask whose release_mask
+// matches release, in config order. Masks with an invalid regex are skipped.
+func (c *Config) Match(release string) []string {
+	tag := strings.SplitN(release, "-", 2)[0]
+	var images []string
+	for _, k := range c.SupportedKernels {
+		re, err := regexp.Compile(k.ReleaseMask)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(release) {
+			images = append(images, strings.ReplaceAll(k.Image, "{{.Tag}}", tag))
+		}
+	}
+	return images
+}
+
+// Entry is one concrete (distro, release, kernel) combination drawn from the
+// matrix the config describes.
+type Entry struct {
+	DistroType    string
+	DistroRelease string
+	Kernel        string
+	Image         string
+	// Mask is the index of the originating SupportedKernels entry. Two masks
+	// can share the same DistroType/DistroRelease (e.g. separate kernel
+	// ranges for the same Ubuntu release), so callers that cap entries per
+	// mask must key on Mask rather than DistroType/DistroRelease.
+	Mask int
+}
+
+// Matrix expands every mask's explicit Kernels list into concrete entries,
+// substituting "{{.Tag}}" in Image the same way Match does.
+func (c *Config) Matrix() []Entry {
+	var entries []Entry
+	for i, k := range c.SupportedKernels {
+		for _, kernel := range k.Kernels {
+			tag := strings.SplitN(kernel, "-", 2)[0]
+			entries = append(entries, Entry{
+				DistroType:    k.DistroType,
+				DistroRelease: k.DistroRelease,
+				Kernel:        kernel,
+				Image:         strings.ReplaceAll(k.Image, "{{.Tag}}", tag),
+				Mask:          i,
+			})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,61 @@
+package local
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/titan-data/titan/internal/app/providers/local/cache"
+)
+
+// ZFSCacheCmd is the parent for `titan zfs cache ...` subcommands.
+var ZFSCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of built and downloaded ZFS kernel modules",
+}
+
+// ZFSCacheListCmd implements `titan zfs cache list`.
+var ZFSCacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached ZFS kernel modules",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		receipts, err := cache.List()
+		if err != nil {
+			return err
+		}
+		if len(receipts) == 0 {
+			fmt.Println("No cached ZFS kernel modules")
+			return nil
+		}
+		for _, r := range receipts {
+			fmt.Printf("%s\tzfs-%s\t%s\t%s\n", r.KernelTag, r.ZFSVersion, r.CreatedAt.Format(time.RFC3339), r.Path)
+		}
+		return nil
+	},
+}
+
+// zfsCachePruneMaxAge is the --max-age flag for `titan zfs cache prune`.
+var zfsCachePruneMaxAge time.Duration
+
+// ZFSCachePruneCmd implements `titan zfs cache prune`.
+var ZFSCachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached ZFS kernel modules older than --max-age",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cache.Prune(zfsCachePruneMaxAge)
+	},
+}
+
+// ZFSCacheClearCmd implements `titan zfs cache clear`.
+var ZFSCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached ZFS kernel modules",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cache.Clear()
+	},
+}
+
+func init() {
+	ZFSCachePruneCmd.Flags().DurationVar(&zfsCachePruneMaxAge, "max-age", 30*24*time.Hour, "remove cache entries older than this")
+	ZFSCacheCmd.AddCommand(ZFSCacheListCmd, ZFSCachePruneCmd, ZFSCacheClearCmd)
+}
@@ -0,0 +1,13 @@
+package local
+
+import "github.com/spf13/cobra"
+
+// ZFSCmd is the parent for `titan zfs ...` subcommands.
+var ZFSCmd = &cobra.Command{
+	Use:   "zfs",
+	Short: "Manage the ZFS kernel modules Titan depends on",
+}
+
+func init() {
+	ZFSCmd.AddCommand(ZFSCacheCmd, ZFSGenallCmd, ZFSListCmd)
+}
@@ -0,0 +1,185 @@
+package local
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/titan-data/titan/internal/app/providers/local/cache"
+	"github.com/titan-data/titan/internal/app/providers/local/kernels"
+)
+
+var (
+	zfsGenallMax     int
+	zfsGenallShuffle bool
+	zfsGenallRetries int
+	zfsGenallPush    bool
+)
+
+// ZFSGenallCmd pre-builds the ZFS kernel module matrix described by
+// kernels.toml, so that maintainers can regenerate coverage for a new
+// Docker Desktop release in one command instead of hand-tagging images.
+var ZFSGenallCmd = &cobra.Command{
+	Use:   "genall",
+	Short: "Pre-build the ZFS kernel module matrix for every supported kernel",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kcfg, err := kernels.Load(kernels.DefaultPath())
+		if err != nil {
+			return err
+		}
+
+		entries := sampleMatrix(kcfg.Matrix(), zfsGenallMax)
+		if zfsGenallShuffle {
+			rand.Shuffle(len(entries), func(i, j int) {
+				entries[i], entries[j] = entries[j], entries[i]
+			})
+		}
+
+		var failed []string
+		for _, e := range entries {
+			fmt.Printf("Building ZFS modules for %s %s (kernel %s)\n", e.DistroType, e.DistroRelease, e.Kernel)
+			if err := buildWithRetries(e.Kernel, zfsGenallRetries); err != nil {
+				fmt.Println("Failed:", err)
+				failed = append(failed, e.Kernel)
+				continue
+			}
+			if zfsGenallPush {
+				fmt.Println("Pushing " + e.Image)
+				if err := pushImage(e.Kernel, e.Image); err != nil {
+					fmt.Println("Failed to push:", err)
+					failed = append(failed, e.Kernel)
+				}
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to build or push %d of %d kernels: %v", len(failed), len(entries), failed)
+		}
+		return nil
+	},
+}
+
+// ZFSListCmd prints the (distro, release, kernel, image) matrix the current
+// kernels.toml would cover, without building anything.
+var ZFSListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the ZFS kernel module matrix the current config covers",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kcfg, err := kernels.Load(kernels.DefaultPath())
+		if err != nil {
+			return err
+		}
+		for _, e := range kcfg.Matrix() {
+			fmt.Printf("%s/%s\t%s\t%s\n", e.DistroType, e.DistroRelease, e.Kernel, e.Image)
+		}
+		return nil
+	},
+}
+
+func init() {
+	ZFSGenallCmd.Flags().IntVar(&zfsGenallMax, "max", 0, "randomly sample up to N kernels per mask (0 = all)")
+	ZFSGenallCmd.Flags().BoolVar(&zfsGenallShuffle, "shuffle", false, "randomize build order")
+	ZFSGenallCmd.Flags().IntVar(&zfsGenallRetries, "retries", 0, "retry transient build failures this many times")
+	ZFSGenallCmd.Flags().BoolVar(&zfsGenallPush, "push", false, "push each built kernel's module as a prebuilt image to its registry")
+}
+
+// sampleMatrix randomly samples up to max entries per originating mask
+// (kernels.Entry.Mask), regardless of --shuffle, so that "--max N" alone
+// still matches its own help text and the request's "randomly sample"
+// behavior rather than silently taking a deterministic prefix. Masks are
+// emitted in order of first appearance; max <= 0 means no cap.
+func sampleMatrix(entries []kernels.Entry, max int) []kernels.Entry {
+	if max <= 0 {
+		return entries
+	}
+
+	byMask := map[int][]kernels.Entry{}
+	var maskOrder []int
+	for _, e := range entries {
+		if _, seen := byMask[e.Mask]; !seen {
+			maskOrder = append(maskOrder, e.Mask)
+		}
+		byMask[e.Mask] = append(byMask[e.Mask], e)
+	}
+
+	var sampled []kernels.Entry
+	for _, mask := range maskOrder {
+		group := byMask[mask]
+		rand.Shuffle(len(group), func(i, j int) {
+			group[i], group[j] = group[j], group[i]
+		})
+		if len(group) > max {
+			group = group[:max]
+		}
+		sampled = append(sampled, group...)
+	}
+	return sampled
+}
+
+// pushImage packages the cached module for kernel into a minimal image
+// tagged image and pushes it, so a later installZFS against the same kernel
+// can hit the Docker Desktop prebuilt-image fast path instead of rebuilding
+// from source. When run, the image copies the module into /lib/modules/extra
+// and loads it.
+func pushImage(kernel, image string) error {
+	tag := strings.SplitN(kernel, "-", 2)[0]
+	modulePath := filepath.Join(cache.Dir(tag, defaultZFSVersion), "zfs.ko")
+	receipts, err := cache.List()
+	if err != nil {
+		return fmt.Errorf("listing cached modules: %w", err)
+	}
+	for _, r := range receipts {
+		if r.KernelTag == tag && r.ZFSVersion == defaultZFSVersion {
+			modulePath = r.Path
+		}
+	}
+
+	buildCtx, err := os.MkdirTemp("", "titan-push-*")
+	if err != nil {
+		return fmt.Errorf("creating push build context: %w", err)
+	}
+	defer os.RemoveAll(buildCtx)
+
+	dockerfile := "FROM alpine:latest\nCOPY zfs.ko /zfs.ko\n" +
+		"CMD cp /zfs.ko /lib/modules/extra/zfs.ko && insmod /lib/modules/extra/zfs.ko\n"
+	if err := os.WriteFile(filepath.Join(buildCtx, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return fmt.Errorf("writing push Dockerfile: %w", err)
+	}
+	module, err := os.ReadFile(modulePath)
+	if err != nil {
+		return fmt.Errorf("reading cached module for %s: %w", kernel, err)
+	}
+	if err := os.WriteFile(filepath.Join(buildCtx, "zfs.ko"), module, 0644); err != nil {
+		return fmt.Errorf("staging push build context: %w", err)
+	}
+
+	if _, err := ce.Exec("docker", "build", "-t", image, buildCtx); err != nil {
+		return fmt.Errorf("building push image %s: %w", image, err)
+	}
+	if _, err := ce.Exec("docker", "push", image); err != nil {
+		return fmt.Errorf("pushing %s: %w", image, err)
+	}
+	return nil
+}
+
+// buildWithRetries calls buildZFSFromSource for kernel, retrying up to
+// retries times with linear backoff on transient failure.
+func buildWithRetries(kernel string, retries int) error {
+	tag := strings.SplitN(kernel, "-", 2)[0]
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt) * 2 * time.Second
+			fmt.Printf("Retrying build for %s in %s (attempt %d/%d)\n", kernel, backoff, attempt+1, retries+1)
+			time.Sleep(backoff)
+		}
+		if err = buildZFSFromSource(tag, "", "", ""); err == nil {
+			return nil
+		}
+	}
+	return err
+}